@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gregdel/pushover"
+)
+
+// Both app tokens and user/group keys are 30 alphanumeric characters
+var keyRegexp = regexp.MustCompile(`^[A-Za-z0-9]{30}$`)
+
+// runCheck prints a pass/fail checklist and reports whether all passed
+func runCheck(app *pushover.Pushover, recipient *pushover.Recipient) bool {
+	ok := true
+	report := func(name string, err error) {
+		if err != nil {
+			fmt.Printf("[%s] %s: %s\n", paint(os.Stdout, colorRed, "FAIL"), name, err)
+			ok = false
+			return
+		}
+		fmt.Printf("[%s] %s\n", paint(os.Stdout, colorGreen, " OK "), name)
+	}
+
+	report("APP_KEY set", present(appKey))
+	report("RECIPENT_KEY set", present(recipentKey))
+	report("APP_KEY format", wellFormed(appKey))
+	report("RECIPENT_KEY format", wellFormed(recipentKey))
+	if !ok {
+		// No point asking the API about keys we know are bad
+		return false
+	}
+
+	// Validate the recipient, which also proves the API is reachable
+	details, err := app.GetRecipientDetails(recipient)
+	// The library leaves a rejected recipient's errors in the details
+	if err == nil && details.Status != 1 {
+		err = details.Errors
+	}
+	report("Pushover reachable, recipient valid", err)
+	if err == nil {
+		// Groups have no devices of their own to list
+		if details.Group == 1 {
+			fmt.Println("       recipient is a delivery group")
+		} else {
+			fmt.Printf("       devices: %s\n", strings.Join(details.Devices, ", "))
+		}
+	}
+
+	return ok
+}
+
+func present(v string) error {
+	if v == "" {
+		return fmt.Errorf("not set")
+	}
+	return nil
+}
+
+func wellFormed(v string) error {
+	if !keyRegexp.MatchString(v) {
+		return fmt.Errorf("expected 30 letters and digits")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gregdel/pushover"
+)
+
+func TestRunCheck(t *testing.T) {
+	api := newAPIStub(t)
+	api.useAPI(t)
+	app := pushover.New(appKey)
+	recipient := pushover.NewRecipient(recipentKey)
+
+	api.setReply(`{"status":1,"request":"r","group":0,"devices":["phone"]}`)
+	if !runCheck(app, recipient) {
+		t.Error("check failed against a validating API")
+	}
+
+	api.setReply(`{"status":0,"request":"r","errors":["user key is invalid"]}`)
+	if runCheck(app, recipient) {
+		t.Error("check passed although the API rejected the recipient")
+	}
+
+	n := api.count()
+	appKey = ""
+	if runCheck(app, recipient) {
+		t.Error("check passed without APP_KEY")
+	}
+	if api.count() != n {
+		t.Error("API called although the keys are bad")
+	}
+}
+
+func TestCheckReportsGroup(t *testing.T) {
+	api := newAPIStub(t)
+	api.setReply(`{"status":1,"request":"r","group":1,"devices":[]}`)
+	stdout, stderr, code := runMain(t, api.env(), "", "-check")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "recipient is a delivery group") || strings.Contains(stdout, "devices:") {
+		t.Errorf("group not reported:\n%s", stdout)
+	}
+
+	api.setReply(`{"status":1,"request":"r","group":0,"devices":["phone","tablet"]}`)
+	stdout, _, _ = runMain(t, api.env(), "", "-check")
+	if !strings.Contains(stdout, "devices: phone, tablet") {
+		t.Errorf("devices not listed:\n%s", stdout)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gregdel/pushover"
+)
+
+// Base identifier sent with every API request
+const userAgent = "pushover-cli"
+
+// userAgentTransport sets the User-Agent header on outgoing requests
+type userAgentTransport struct {
+	agent string
+	next  http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrip must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.agent)
+	return t.next.RoundTrip(req)
+}
+
+// setupHTTPClient configures the client used by the pushover library,
+// which always sends through http.DefaultClient. The standard proxy
+// variables are honored unless proxyURL overrides them.
+func setupHTTPClient(clientID, proxyURL string) error {
+	next := http.DefaultTransport
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", proxyURL)
+		}
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = http.ProxyURL(u)
+		next = t
+	}
+	http.DefaultClient.Transport = &userAgentTransport{
+		agent: userAgentString(clientID),
+		next:  next,
+	}
+	return nil
+}
+
+// setAPIBaseURL points the pushover library at an alternative API base
+func setAPIBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid API base URL %q", baseURL)
+	}
+	pushover.APIEndpoint = strings.TrimSuffix(baseURL, "/")
+	return nil
+}
+
+// userAgentString appends the optional client identifier to userAgent
+func userAgentString(clientID string) string {
+	if clientID == "" {
+		return userAgent
+	}
+	return userAgent + " (" + clientID + ")"
+}
+
+// appLimits is the response of the apps/limits API
+type appLimits struct {
+	Status    int             `json:"status"`
+	Limit     int             `json:"limit"`
+	Remaining int             `json:"remaining"`
+	Reset     int64           `json:"reset"`
+	Errors    pushover.Errors `json:"errors"`
+}
+
+// getLimits queries the monthly message quota of the app without
+// sending anything
+func getLimits(token string) (*appLimits, error) {
+	endpoint := fmt.Sprintf("%s/apps/limits.json?token=%s", pushover.APIEndpoint, url.QueryEscape(token))
+	resp, err := http.DefaultClient.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, pushover.ErrHTTPPushover
+	}
+
+	var limits appLimits
+	if err := json.NewDecoder(resp.Body).Decode(&limits); err != nil {
+		return nil, err
+	}
+	if limits.Status != 1 {
+		return nil, limits.Errors
+	}
+	return &limits, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gregdel/pushover"
+)
+
+func TestUserAgent(t *testing.T) {
+	restoreHTTPClient(t)
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	for id, want := range map[string]string{
+		"":      "pushover-cli",
+		"ci-01": "pushover-cli (ci-01)",
+	} {
+		if err := setupHTTPClient(id, ""); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got != want {
+			t.Errorf("User-Agent = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestProxyURL(t *testing.T) {
+	restoreHTTPClient(t)
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxy sees the absolute target URL
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	if err := setupHTTPClient("", proxy.URL); err != nil {
+		t.Fatal(err)
+	}
+	target := "http://api.pushover.invalid/1/glances.json"
+	resp, err := http.DefaultClient.Get(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if proxied != target {
+		t.Errorf("proxy saw %q, want %q", proxied, target)
+	}
+}
+
+func TestProxyURLInvalid(t *testing.T) {
+	restoreHTTPClient(t)
+	for _, bad := range []string{"proxy", "http://", "://proxy:3128"} {
+		if err := setupHTTPClient("", bad); err == nil {
+			t.Errorf("proxy URL %q accepted", bad)
+		}
+	}
+}
+
+func TestAPIBaseURL(t *testing.T) {
+	api := newAPIStub(t)
+	api.useAPI(t)
+	if err := setAPIBaseURL(api.URL + "/1/"); err != nil {
+		t.Fatal(err)
+	}
+	app := pushover.New(testAppKey)
+	_, err := app.SendGlanceUpdate(&pushover.Glance{Title: pushover.String("t")}, pushover.NewRecipient(testRecipientKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := api.paths[0]; got != "/1/glances.json" {
+		t.Errorf("request path %q, want /1/glances.json", got)
+	}
+
+	for _, bad := range []string{"api.example.com", "ftp://api.example.com", "https://"} {
+		if err := setAPIBaseURL(bad); err == nil {
+			t.Errorf("base URL %q accepted", bad)
+		}
+	}
+}
+
+func TestGetLimits(t *testing.T) {
+	api := newAPIStub(t)
+	api.useAPI(t)
+	api.setReply(`{"status":1,"request":"r","limit":10000,"remaining":7496,"reset":1393653600}`)
+
+	limits, err := getLimits(testAppKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.Limit != 10000 || limits.Remaining != 7496 || limits.Reset != 1393653600 {
+		t.Errorf("limits %+v", limits)
+	}
+	if api.paths[0] != "/apps/limits.json" || api.last().Get("token") != testAppKey {
+		t.Errorf("queried %s with %v", api.paths[0], api.last())
+	}
+
+	api.setReply(`{"status":0,"request":"r","errors":["application token is invalid"]}`)
+	if _, err := getLimits(testAppKey); err == nil || !strings.Contains(err.Error(), "token is invalid") {
+		t.Errorf("want API error, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// printCompletion writes a completion script for shell covering every
+// flag registered on fs, so new flags are picked up automatically
+func printCompletion(w io.Writer, fs *flag.FlagSet, shell string) error {
+	switch shell {
+	case "bash":
+		var opts []string
+		fs.VisitAll(func(f *flag.Flag) {
+			opts = append(opts, "-"+f.Name)
+		})
+		fmt.Fprintf(w, "complete -W %q pushover\n", strings.Join(opts, " "))
+	case "zsh":
+		fmt.Fprintln(w, "#compdef pushover")
+		fmt.Fprintln(w, "_arguments \\")
+		fs.VisitAll(func(f *flag.Flag) {
+			spec := "-" + f.Name + "[" + strings.ReplaceAll(f.Usage, "'", "") + "]"
+			if !isBoolFlag(f) {
+				spec += ":value:"
+			}
+			fmt.Fprintf(w, "  '%s' \\\n", spec)
+		})
+		fmt.Fprintln(w, "  '*:argument:'")
+	case "fish":
+		fs.VisitAll(func(f *flag.Flag) {
+			required := ""
+			if !isBoolFlag(f) {
+				required = " -r"
+			}
+			fmt.Fprintf(w, "complete -c pushover -o %s%s -d %q\n", f.Name, required, f.Usage)
+		})
+	default:
+		return fmt.Errorf("unsupported shell %q, use bash, zsh or fish", shell)
+	}
+	return nil
+}
+
+// isBoolFlag reports whether f is a switch that takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
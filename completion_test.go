@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestPrintCompletion(t *testing.T) {
+	fs := flag.NewFlagSet("pushover", flag.ContinueOnError)
+	fs.Bool("check", false, "run self-diagnostics")
+	fs.String("d", "", "device name(s)")
+	fs.String("queue-dir", "", "queue directory")
+
+	want := map[string][]string{
+		"bash": {"-check", "-d", "-queue-dir"},
+		"zsh":  {"'-check[run self-diagnostics]'", "'-d[device name(s)]:value:'", "'-queue-dir[queue directory]:value:'"},
+		"fish": {"-o check -d", "-o d -r", "-o queue-dir -r"},
+	}
+	for shell, parts := range want {
+		var b bytes.Buffer
+		if err := printCompletion(&b, fs, shell); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range parts {
+			if !strings.Contains(b.String(), p) {
+				t.Errorf("%s script lacks %q:\n%s", shell, p, b.String())
+			}
+		}
+	}
+
+	if err := printCompletion(&bytes.Buffer{}, fs, "tcsh"); err == nil {
+		t.Error("unsupported shell accepted")
+	}
+}
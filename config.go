@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// effectiveConfig is the resolved configuration as shown by -show-config
+type effectiveConfig struct {
+	EnvFile     string `json:"env_file"`
+	EnvPrefix   string `json:"env_prefix"`
+	AppKey      string `json:"app_key"`
+	RecipentKey string `json:"recipent_key"`
+	Device      string `json:"device"`
+	Truncate    bool   `json:"truncate"`
+	TitleTmpl   bool   `json:"title_template"`
+	UserAgent   string `json:"user_agent"`
+	PreSendHook string `json:"pre_send_hook"`
+	PostSendURL string `json:"post_send_webhook"`
+	ProxyURL    string `json:"proxy_url"`
+	APIBaseURL  string `json:"api_base_url"`
+}
+
+// showConfig writes the configuration to w with secrets redacted
+func showConfig(w io.Writer, cfg effectiveConfig, asJSON bool) error {
+	cfg.AppKey = redact(cfg.AppKey)
+	cfg.RecipentKey = redact(cfg.RecipentKey)
+	// Proxy URLs may embed a password; webhook URLs usually carry their
+	// token in the path or query
+	cfg.ProxyURL = redactURL(cfg.ProxyURL)
+	cfg.PostSendURL = redactWebhook(cfg.PostSendURL)
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cfg)
+	}
+	fmt.Fprintf(w, "env file:     %s\n", cfg.EnvFile)
+	fmt.Fprintf(w, "env prefix:   %s\n", cfg.EnvPrefix)
+	fmt.Fprintf(w, "app key:      %s\n", cfg.AppKey)
+	fmt.Fprintf(w, "recipent key: %s\n", cfg.RecipentKey)
+	fmt.Fprintf(w, "device:       %s\n", cfg.Device)
+	fmt.Fprintf(w, "truncate:     %t\n", cfg.Truncate)
+	fmt.Fprintf(w, "title tmpl:   %t\n", cfg.TitleTmpl)
+	fmt.Fprintf(w, "user agent:   %s\n", cfg.UserAgent)
+	fmt.Fprintf(w, "pre-send:     %s\n", cfg.PreSendHook)
+	fmt.Fprintf(w, "post-send:    %s\n", cfg.PostSendURL)
+	fmt.Fprintf(w, "proxy url:    %s\n", cfg.ProxyURL)
+	fmt.Fprintf(w, "api base url: %s\n", cfg.APIBaseURL)
+	return nil
+}
+
+// redactURL hides the password in a URL's userinfo
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Redacted()
+}
+
+// redactWebhook keeps only the scheme and host of a webhook URL
+func redactWebhook(s string) string {
+	if s == "" {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return "(redacted)"
+	}
+	out := u.Scheme + "://" + u.Host
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		out += "/(redacted)"
+	}
+	return out
+}
+
+// redact keeps only the last four characters of a secret
+func redact(s string) string {
+	if s == "" {
+		return "(not set)"
+	}
+	if len(s) <= 4 {
+		return strings.Repeat("*", len(s))
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+}
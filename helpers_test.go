@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gregdel/pushover"
+)
+
+// Test keys in the format the library accepts
+const (
+	testAppKey       = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	testRecipientKey = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+// TestMain runs main instead of the tests when re-executed by runMain
+func TestMain(m *testing.M) {
+	if os.Getenv("PUSHOVER_TEST_MAIN") == "1" {
+		var args []string
+		if err := json.Unmarshal([]byte(os.Getenv("PUSHOVER_TEST_ARGS")), &args); err != nil {
+			panic(err)
+		}
+		os.Args = append([]string{"pushover"}, args...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs main in a child process with only env set, returning its
+// output and exit code
+func runMain(t *testing.T, env []string, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	return runMainInput(t, env, strings.NewReader(stdin), args...)
+}
+
+// runMainInput is runMain reading stdin from r. The child is killed if it
+// has not finished within 30 seconds.
+func runMainInput(t *testing.T, env []string, r io.Reader, args ...string) (string, string, int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0])
+	cmd.Dir = t.TempDir()
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd.Env = append([]string{
+		"PATH=" + os.Getenv("PATH"),
+		"PUSHOVER_TEST_MAIN=1",
+		"PUSHOVER_TEST_ARGS=" + string(encoded),
+	}, env...)
+	cmd.Stdin = r
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		t.Fatalf("%v did not finish in time", args)
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// apiStub is a fake Pushover API recording the requests it receives
+type apiStub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []url.Values
+	paths    []string
+	// reply is the JSON body returned for the next requests
+	reply string
+	// status is the HTTP status returned for the next requests
+	status int
+}
+
+func newAPIStub(t *testing.T) *apiStub {
+	t.Helper()
+	s := &apiStub{reply: `{"status":1,"request":"req-1"}`, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *apiStub) handle(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Form)
+	s.paths = append(s.paths, r.URL.Path)
+	reply, status := s.reply, s.status
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Limit-App-Limit", "10000")
+	w.Header().Set("X-Limit-App-Remaining", "9999")
+	w.Header().Set("X-Limit-App-Reset", "1700000000")
+	w.WriteHeader(status)
+	fmt.Fprint(w, reply)
+}
+
+func (s *apiStub) setReply(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = reply
+}
+
+func (s *apiStub) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *apiStub) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func (s *apiStub) last() url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return url.Values{}
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+// env returns the environment pointing main at the stub
+func (s *apiStub) env() []string {
+	return []string{
+		"APP_KEY=" + testAppKey,
+		"RECIPENT_KEY=" + testRecipientKey,
+		"PUSHOVER_API_BASE_URL=" + s.URL,
+	}
+}
+
+// useAPI points the library at the stub for the rest of the test and
+// sets the keys loadEnv would
+func (s *apiStub) useAPI(t *testing.T) {
+	t.Helper()
+	old, oldApp, oldRecipient := pushover.APIEndpoint, appKey, recipentKey
+	t.Cleanup(func() {
+		pushover.APIEndpoint, appKey, recipentKey = old, oldApp, oldRecipient
+	})
+	pushover.APIEndpoint = s.URL
+	appKey, recipentKey = testAppKey, testRecipientKey
+}
+
+// restoreHTTPClient undoes setupHTTPClient at the end of the test
+func restoreHTTPClient(t *testing.T) {
+	old := http.DefaultClient.Transport
+	t.Cleanup(func() { http.DefaultClient.Transport = old })
+}
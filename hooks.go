@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/gregdel/pushover"
+)
+
+// Upper bound on how long a hook may run, shortened by tests
+var hookTimeout = 10 * time.Second
+
+// runPreSendHook runs the shell command hook with the glance as JSON on
+// stdin. A nonzero exit, or running past hookTimeout, aborts the send.
+func runPreSendHook(hook string, glance *pushover.Glance) error {
+	data, err := json.Marshal(glance)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("pre-send hook timed out after %s", hookTimeout)
+		}
+		return fmt.Errorf("pre-send hook blocked the send: %w", err)
+	}
+	return nil
+}
+
+// webhookPayload is posted to the post-send webhook
+type webhookPayload struct {
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Recipient string `json:"recipient"`
+}
+
+// notifyWebhook reports the outcome of a send to recipient to webhook.
+// Failures are only logged, they never change the result of the send.
+func notifyWebhook(webhook, recipient string, response *pushover.Response, sendErr error) {
+	payload := webhookPayload{
+		Result:    "sent",
+		Recipient: redact(recipient),
+	}
+	if response != nil {
+		payload.RequestID = response.ID
+	}
+	if sendErr != nil {
+		payload.Result = "failed"
+		payload.Error = sendErr.Error()
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		warnf("Post-send webhook: %s", err)
+		return
+	}
+
+	client := &http.Client{
+		Timeout:   hookTimeout,
+		Transport: http.DefaultClient.Transport,
+	}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		warnf("Post-send webhook: %s", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		warnf("Post-send webhook: %s", resp.Status)
+	}
+}
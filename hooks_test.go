@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gregdel/pushover"
+)
+
+func TestPreSendHook(t *testing.T) {
+	glance := &pushover.Glance{Title: pushover.String("deploy")}
+
+	if err := runPreSendHook("true", glance); err != nil {
+		t.Errorf("allowing hook blocked the send: %s", err)
+	}
+	if err := runPreSendHook(`grep -q '"Title":"deploy"'`, glance); err != nil {
+		t.Errorf("hook did not receive the glance on stdin: %s", err)
+	}
+	if err := runPreSendHook("exit 1", glance); err == nil {
+		t.Error("blocking hook allowed the send")
+	}
+
+	old := hookTimeout
+	hookTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { hookTimeout = old })
+	start := time.Now()
+	err := runPreSendHook("exec sleep 5", glance)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("want timeout error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("hook was not stopped at the timeout")
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	payloads := make(chan webhookPayload, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Error(err)
+		}
+		payloads <- p
+	}))
+	defer srv.Close()
+
+	notifyWebhook(srv.URL, testRecipientKey, &pushover.Response{ID: "req-1"}, nil)
+	got := <-payloads
+	want := webhookPayload{Result: "sent", RequestID: "req-1", Recipient: redact(testRecipientKey)}
+	if got != want {
+		t.Errorf("payload %+v, want %+v", got, want)
+	}
+
+	notifyWebhook(srv.URL, testRecipientKey, nil, errors.New("pushover: http error"))
+	got = <-payloads
+	if got.Result != "failed" || got.Error != "pushover: http error" || got.RequestID != "" {
+		t.Errorf("failure payload %+v", got)
+	}
+	if strings.Contains(got.Recipient, testRecipientKey) {
+		t.Error("recipient key not redacted")
+	}
+}
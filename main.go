@@ -1,25 +1,164 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gregdel/pushover"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/joho/godotenv"
 )
 
+// Settings read from the environment by loadEnv
 var (
-	appKey      = os.Getenv("APP_KEY")
-	recipentKey = os.Getenv("RECIPENT_KEY")
+	appKey      string
+	recipentKey string
+	// Truncate over-long fields instead of letting the API reject them
+	truncate bool
+	// Custom identifier appended to the User-Agent
+	clientID string
+	// Expand {{.Time}} and {{.Host}} in the title
+	titleTemplate bool
+	// Shell command that may veto a send
+	preSendHook string
+	// URL notified with the outcome of each send
+	postSendWebhook string
+	// Proxy for API requests, overriding HTTP_PROXY/HTTPS_PROXY
+	proxyURL string
+	// Alternative Pushover-compatible API base URL
+	apiBaseURL string
+)
+
+var (
+	// Same format the Pushover API accepts for a device name. The library
+	// checks this too; checking here only gives a clearer error message.
+	deviceNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,25}$`)
 )
 
 func main() {
+	// Comma separated list of devices, e.g. -d phone,tablet
+	device := flag.String("d", "iPhoneIX", "device name(s), comma separated")
+	// Upstream exit status, e.g. -exit-code $?; success sends nothing
+	exitCode := flag.Int("exit-code", -1, "exit status of the previous command, only send if nonzero")
+	check := flag.Bool("check", false, "run self-diagnostics and exit")
+	limitCheck := flag.Bool("limit-check", false, "print the app's remaining message quota and exit")
+	envFile := flag.String("env-file", "", "load environment from this file instead of .env")
+	envPrefix := flag.String("env-prefix", "", "prefix for all environment variable names, e.g. ALERTS_")
+	showCfg := flag.Bool("show-config", false, "print the effective configuration and exit")
+	asJSON := flag.Bool("json", false, "with -show-config, print JSON")
+	firstLine := flag.Bool("title-from-first-line", false, "read stdin, using the first line as title and the rest as text")
+	messageFile := flag.String("message-file", "", "read the text from this file instead of the second argument")
+	after := flag.Duration("after", 0, "wait this long before sending, e.g. 30m")
+	at := flag.String("at", "", "send at this local time, e.g. 2024-01-01T09:00:00")
+	count := flag.Int("count", 0, "badge count to show, may be negative")
+	percent := flag.Int("percent", 0, "progress to show, 0-100")
+	queueDir := flag.String("queue-dir", "", "queue updates that fail to send in this directory")
+	flush := flag.Bool("flush-queue", false, "resend updates queued in -queue-dir and exit")
+	flag.StringVar(&colorMode, "color", "auto", "color output: auto, always or never")
+	preview := flag.Bool("preview", false, "print how the update would look and exit without sending")
+	completion := flag.String("completion", "", "print a completion script for bash, zsh or fish and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] title [text]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if colorMode != "auto" && colorMode != "always" && colorMode != "never" {
+		fatal(fmt.Errorf("invalid -color %q, use auto, always or never", colorMode))
+	}
+	if *completion != "" {
+		if err := printCompletion(os.Stdout, flag.CommandLine, *completion); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	prefix, err := loadEnv(*envFile, *envPrefix)
+	if err != nil {
+		fatal(err)
+	}
+	if *showCfg {
+		cfg := effectiveConfig{
+			EnvFile:     *envFile,
+			EnvPrefix:   prefix,
+			AppKey:      appKey,
+			RecipentKey: recipentKey,
+			Device:      *device,
+			Truncate:    truncate,
+			TitleTmpl:   titleTemplate,
+			UserAgent:   userAgentString(clientID),
+			PreSendHook: preSendHook,
+			PostSendURL: postSendWebhook,
+			ProxyURL:    proxyURL,
+			APIBaseURL:  apiBaseURL,
+		}
+		if cfg.EnvFile == "" {
+			cfg.EnvFile = ".env"
+		}
+		if cfg.APIBaseURL == "" {
+			cfg.APIBaseURL = pushover.APIEndpoint
+		}
+		if err := showConfig(os.Stdout, cfg, *asJSON); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	sendAt, err := scheduledTime(*after, *at, time.Now())
+	if err != nil {
+		fatal(err)
+	}
+	if err := validateDevices(*device); err != nil {
+		fatal(err)
+	}
+
+	if err := setupHTTPClient(clientID, proxyURL); err != nil {
+		fatal(err)
+	}
+	if err := setAPIBaseURL(apiBaseURL); err != nil {
+		fatal(err)
+	}
+
 	// Create a new pushover app with a token
 	app := pushover.New(appKey)
 
 	// Create a new recipient
 	recipient := pushover.NewRecipient(recipentKey)
 
+	if *check {
+		if !runCheck(app, recipient) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *limitCheck {
+		limits, err := getLimits(appKey)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("limit:     %d\n", limits.Limit)
+		fmt.Printf("remaining: %d\n", limits.Remaining)
+		fmt.Printf("reset:     %s\n", time.Unix(limits.Reset, 0))
+		return
+	}
+
+	if *flush {
+		if *queueDir == "" {
+			fatal(errors.New("-flush-queue requires -queue-dir"))
+		}
+		if err := flushQueue(*queueDir, app, recipient); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
 	// Create the message to send
 	// message := pushover.NewMessageWithTitle(os.Args[1], os.Args[2])
 
@@ -30,17 +169,171 @@ func main() {
 
 	// Print the response if you want
 	// log.Println(response)
-	title := os.Args[1]
-	text := os.Args[2]
-	count := 420
-	pct := 69
-	// Test Glances API
-	fmt.Println(app.SendGlanceUpdate(&pushover.Glance{
+	if flag.NArg() == 0 && !*firstLine {
+		flag.Usage()
+		os.Exit(2)
+	}
+	// Check conflicts before reading stdin, which may never reach EOF
+	if *firstLine && flag.NArg() > 0 {
+		fatal(errors.New("-title-from-first-line takes no title or text arguments"))
+	}
+	if *messageFile != "" && (*firstLine || flag.NArg() > 1) {
+		fatal(errors.New("-message-file cannot be combined with a text argument or -title-from-first-line"))
+	}
+	title := flag.Arg(0)
+	text := flag.Arg(1)
+	if *firstLine {
+		if title, text, err = readTitleAndText(os.Stdin); err != nil {
+			fatal(err)
+		}
+	}
+	if *messageFile != "" {
+		data, err := os.ReadFile(*messageFile)
+		if err != nil {
+			fatal(err)
+		}
+		text = strings.TrimRight(string(data), "\r\n")
+	}
+	// The previous command succeeded, nothing to report
+	if *exitCode == 0 {
+		return
+	}
+	if !sendAt.IsZero() && !*preview {
+		// Ctrl-C or SIGTERM while waiting cancels the send
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := waitUntil(ctx, sendAt)
+		stop()
+		if err != nil {
+			fatal(err)
+		}
+	}
+	if titleTemplate {
+		if title, err = renderTitle(title, time.Now(), hostname()); err != nil {
+			fatal(fmt.Errorf("invalid title template: %w", err))
+		}
+	}
+	fullTitle, fullText := title, text
+	title, text = truncateFields(title, text)
+	glance := &pushover.Glance{
 		Title:      &title,
 		Text:       &text,
-		Count:      &count,
-		Percent:    &pct,
-		DeviceName: "iPhoneIX",
-	}, recipient))
+		DeviceName: *device,
+	}
+	// Only send count and percent when asked to, zero is a valid value
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "count":
+			glance.Count = count
+		case "percent":
+			glance.Percent = percent
+		}
+	})
+	if *preview {
+		printPreview(os.Stdout, glance, title != fullTitle, text != fullText)
+		return
+	}
+	if preSendHook != "" {
+		if err := runPreSendHook(preSendHook, glance); err != nil {
+			fatal(err)
+		}
+	}
+	// Test Glances API
+	response, err := app.SendGlanceUpdate(glance, recipient)
+	if err != nil && *queueDir != "" && retryable(err) {
+		if qerr := enqueue(*queueDir, glance); qerr != nil {
+			warnf("%s", qerr)
+		} else {
+			warnf("Send failed, update queued for -flush-queue")
+		}
+	}
+	if postSendWebhook != "" {
+		notifyWebhook(postSendWebhook, recipentKey, response, err)
+	}
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Println(paint(os.Stdout, colorGreen, response.String()))
+}
+
+// loadEnv loads the dotenv file and reads the settings, returning the
+// prefix it used. Variables already set in the environment always win over
+// the file.
+func loadEnv(envFile, prefix string) (string, error) {
+	if envFile != "" {
+		if err := godotenv.Load(envFile); err != nil {
+			return "", fmt.Errorf("cannot load env file %s: %w", envFile, err)
+		}
+	} else {
+		// .env in the working directory is optional
+		_ = godotenv.Load()
+	}
+
+	// The flag wins over PUSHOVER_ENV_PREFIX, which is never prefixed
+	if prefix == "" {
+		prefix = os.Getenv("PUSHOVER_ENV_PREFIX")
+	}
+	getenv := func(key string) string {
+		return os.Getenv(prefix + key)
+	}
+
+	appKey = getenv("APP_KEY")
+	recipentKey = getenv("RECIPENT_KEY")
+	truncate = getenv("PUSHOVER_TRUNCATE") == "true"
+	clientID = getenv("PUSHOVER_USER_AGENT")
+	titleTemplate = getenv("PUSHOVER_TITLE_TEMPLATE") == "true"
+	preSendHook = getenv("PUSHOVER_PRE_SEND_HOOK")
+	postSendWebhook = getenv("PUSHOVER_POST_SEND_WEBHOOK")
+	proxyURL = getenv("PUSHOVER_PROXY_URL")
+	apiBaseURL = getenv("PUSHOVER_API_BASE_URL")
+	return prefix, nil
+}
+
+// readTitleAndText splits r into its first line and the remaining text
+func readTitleAndText(r io.Reader) (string, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", "", err
+	}
+	input := strings.TrimSpace(string(data))
+	if input == "" {
+		return "", "", errors.New("no title on stdin")
+	}
+	// strings.Cut needs Go 1.18
+	lines := strings.SplitN(input, "\n", 2)
+	if len(lines) == 1 {
+		return strings.TrimSpace(lines[0]), "", nil
+	}
+	return strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1]), nil
+}
+
+// truncateFields applies the glance length limits when PUSHOVER_TRUNCATE
+// is on, otherwise the fields are left for the API to reject
+func truncateFields(title, text string) (string, string) {
+	if !truncate {
+		return title, text
+	}
+	return truncateField("title", title, pushover.GlancesMessageMaxTitleLength),
+		truncateField("text", text, pushover.GlancesMessageMaxTextLength)
+}
+
+// truncateField shortens s to max characters, ending with an ellipsis
+func truncateField(name, s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	warnf("%s longer than %d characters, truncating", name, max)
+	return string([]rune(s)[:max-1]) + "…"
+}
 
+// validateDevices checks every entry of a comma separated device list
+func validateDevices(devices string) error {
+	if devices == "" {
+		return nil
+	}
+	for _, d := range strings.Split(devices, ",") {
+		if !deviceNameRegexp.MatchString(d) {
+			return fmt.Errorf("invalid device name %q", d)
+		}
+	}
+	return nil
 }
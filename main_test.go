@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateFields(t *testing.T) {
+	long := strings.Repeat("é", 101)
+	limit := strings.Repeat("b", 100)
+
+	t.Setenv("PUSHOVER_TRUNCATE", "true")
+	if _, err := loadEnv("", ""); err != nil {
+		t.Fatal(err)
+	}
+	title, text := truncateFields(long, limit)
+	if n := utf8.RuneCountInString(title); n != 100 {
+		t.Errorf("truncated title has %d runes, want 100", n)
+	}
+	if !strings.HasSuffix(title, "…") {
+		t.Errorf("truncated title %q does not end with an ellipsis", title)
+	}
+	if text != limit {
+		t.Errorf("text at the limit was changed to %q", text)
+	}
+
+	t.Setenv("PUSHOVER_TRUNCATE", "")
+	if _, err := loadEnv("", ""); err != nil {
+		t.Fatal(err)
+	}
+	title, _ = truncateFields(long, limit)
+	if title != long {
+		t.Errorf("title changed with truncation off: %q", title)
+	}
+}
+
+func TestValidateDevices(t *testing.T) {
+	if err := validateDevices("phone,tablet"); err != nil {
+		t.Errorf("valid device list rejected: %s", err)
+	}
+	err := validateDevices("phone,my tablet")
+	if err == nil || !strings.Contains(err.Error(), `"my tablet"`) {
+		t.Errorf("want error naming the invalid device, got %v", err)
+	}
+}
+
+func TestMultipleDevicesSent(t *testing.T) {
+	api := newAPIStub(t)
+	_, stderr, code := runMain(t, api.env(), "", "-d", "phone,tablet", "title", "text")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if got := api.last().Get("device"); got != "phone,tablet" {
+		t.Errorf("device = %q, want phone,tablet", got)
+	}
+}
+
+func TestNoTitlePrintsUsage(t *testing.T) {
+	api := newAPIStub(t)
+	_, stderr, code := runMain(t, api.env(), "")
+	if code == 0 {
+		t.Error("exit 0 without a title")
+	}
+	if !strings.Contains(stderr, "Usage:") {
+		t.Errorf("no usage printed: %s", stderr)
+	}
+	if n := api.count(); n != 0 {
+		t.Errorf("%d requests sent without a title", n)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	api := newAPIStub(t)
+	_, stderr, code := runMain(t, api.env(), "", "-exit-code", "0", "title", "text")
+	if code != 0 || api.count() != 0 {
+		t.Errorf("exit-code 0: exit %d, %d requests: %s", code, api.count(), stderr)
+	}
+
+	_, stderr, code = runMain(t, api.env(), "", "-exit-code", "1", "title", "text")
+	if code != 0 || api.count() != 1 {
+		t.Errorf("exit-code 1: exit %d, %d requests: %s", code, api.count(), stderr)
+	}
+}
+
+func TestExitCodeZeroStillRunsCheck(t *testing.T) {
+	api := newAPIStub(t)
+	stdout, _, _ := runMain(t, api.env(), "", "-exit-code", "0", "-check")
+	if !strings.Contains(stdout, "APP_KEY set") {
+		t.Errorf("-check skipped with -exit-code 0: %q", stdout)
+	}
+}
+
+func TestEnvFileFlag(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "alerts.env")
+	data := "APP_KEY=cccccccccccccccccccccccccccc1234\nPUSHOVER_USER_AGENT=from-file\n"
+	if err := os.WriteFile(envFile, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, code := runMain(t, nil, "", "-env-file", envFile, "-show-config", "-json")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var cfg effectiveConfig
+	if err := json.Unmarshal([]byte(stdout), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(cfg.AppKey, "1234") || cfg.UserAgent != "pushover-cli (from-file)" {
+		t.Errorf("env file not applied: %+v", cfg)
+	}
+}
+
+func TestShowConfigEnvPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		env  []string
+		args []string
+		want string
+	}{
+		{nil, nil, ""},
+		{[]string{"PUSHOVER_ENV_PREFIX=ALERTS_"}, nil, "ALERTS_"},
+		{[]string{"PUSHOVER_ENV_PREFIX=ALERTS_"}, []string{"-env-prefix", "OPS_"}, "OPS_"},
+	} {
+		args := append(tc.args, "-show-config", "-json")
+		stdout, stderr, code := runMain(t, tc.env, "", args...)
+		if code != 0 {
+			t.Fatalf("%v: exit %d: %s", args, code, stderr)
+		}
+		var cfg effectiveConfig
+		if err := json.Unmarshal([]byte(stdout), &cfg); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.EnvPrefix != tc.want {
+			t.Errorf("%v %v: env prefix %q, want %q", tc.env, args, cfg.EnvPrefix, tc.want)
+		}
+	}
+}
+
+func TestEnvFileMissing(t *testing.T) {
+	_, stderr, code := runMain(t, nil, "", "-env-file", "/nonexistent/.env", "title")
+	if code == 0 || !strings.Contains(stderr, "cannot load env file") {
+		t.Errorf("exit %d, stderr %q", code, stderr)
+	}
+}
+
+func TestCountAndPercentOnlyWhenGiven(t *testing.T) {
+	api := newAPIStub(t)
+	for _, tc := range []struct {
+		args    []string
+		count   string
+		percent string
+	}{
+		{nil, "", ""},
+		{[]string{"-count", "0"}, "0", ""},
+		{[]string{"-count", "-3", "-percent", "50"}, "-3", "50"},
+	} {
+		args := append(tc.args, "title")
+		_, stderr, code := runMain(t, api.env(), "", args...)
+		if code != 0 {
+			t.Fatalf("%v: exit %d: %s", args, code, stderr)
+		}
+		sent := api.last()
+		if _, ok := sent["count"]; ok != (tc.count != "") || sent.Get("count") != tc.count {
+			t.Errorf("%v: count %v, want %q", args, sent["count"], tc.count)
+		}
+		if _, ok := sent["percent"]; ok != (tc.percent != "") || sent.Get("percent") != tc.percent {
+			t.Errorf("%v: percent %v, want %q", args, sent["percent"], tc.percent)
+		}
+	}
+}
+
+func TestFailedSendExitsNonzero(t *testing.T) {
+	api := newAPIStub(t)
+	api.setStatus(http.StatusBadRequest)
+	api.setReply(`{"status":0,"request":"r","errors":["user identifier is invalid"]}`)
+	_, stderr, code := runMain(t, api.env(), "", "title", "text")
+	if code != 1 {
+		t.Errorf("exit %d, want 1", code)
+	}
+	if !strings.Contains(stderr, "user identifier is invalid") {
+		t.Errorf("API errors not reported: %q", stderr)
+	}
+}
+
+func TestReadTitleAndText(t *testing.T) {
+	title, text, err := readTitleAndText(strings.NewReader("Build failed\nstep 3 of 5\nexit status 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Build failed" || text != "step 3 of 5\nexit status 2" {
+		t.Errorf("split into %q and %q", title, text)
+	}
+
+	title, text, err = readTitleAndText(strings.NewReader("Only a title\n"))
+	if err != nil || title != "Only a title" || text != "" {
+		t.Errorf("single line: %q, %q, %v", title, text, err)
+	}
+
+	for _, empty := range []string{"", " \n\t\n"} {
+		if _, _, err := readTitleAndText(strings.NewReader(empty)); err == nil {
+			t.Errorf("empty input %q accepted", empty)
+		}
+	}
+}
+
+func TestEnvPrefix(t *testing.T) {
+	oldApp, oldRecipient := appKey, recipentKey
+	t.Cleanup(func() { appKey, recipentKey = oldApp, oldRecipient })
+	t.Setenv("APP_KEY", "plain-app")
+	t.Setenv("RECIPENT_KEY", "plain-recipient")
+	t.Setenv("ALERTS_APP_KEY", "alerts-app")
+	t.Setenv("ALERTS_RECIPENT_KEY", "alerts-recipient")
+
+	t.Setenv("PUSHOVER_ENV_PREFIX", "")
+	if _, err := loadEnv("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if appKey != "plain-app" || recipentKey != "plain-recipient" {
+		t.Errorf("no prefix: got %q, %q", appKey, recipentKey)
+	}
+
+	if _, err := loadEnv("", "ALERTS_"); err != nil {
+		t.Fatal(err)
+	}
+	if appKey != "alerts-app" || recipentKey != "alerts-recipient" {
+		t.Errorf("-env-prefix: got %q, %q", appKey, recipentKey)
+	}
+
+	t.Setenv("PUSHOVER_ENV_PREFIX", "ALERTS_")
+	prefix, err := loadEnv("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if appKey != "alerts-app" || prefix != "ALERTS_" {
+		t.Errorf("PUSHOVER_ENV_PREFIX: got %q with prefix %q", appKey, prefix)
+	}
+}
+
+func TestMessageFile(t *testing.T) {
+	api := newAPIStub(t)
+	file := filepath.Join(t.TempDir(), "disk.txt")
+	if err := os.WriteFile(file, []byte("disk 91% full\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, code := runMain(t, api.env(), "", "-message-file", file, "title")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if got := api.last().Get("text"); got != "disk 91% full" {
+		t.Errorf("text = %q", got)
+	}
+
+	n := api.count()
+	_, stderr, code = runMain(t, api.env(), "", "-message-file", file, "title", "text")
+	if code == 0 || !strings.Contains(stderr, "cannot be combined") {
+		t.Errorf("exit %d, stderr %q", code, stderr)
+	}
+	if api.count() != n {
+		t.Error("sent despite conflicting text")
+	}
+}
+
+func TestConflictsReportedBeforeReadingStdin(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "text.txt")
+	if err := os.WriteFile(file, []byte("text"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A stdin that never reaches EOF while the child runs
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	api := newAPIStub(t)
+	_, stderr, code := runMainInput(t, api.env(), r, "-title-from-first-line", "-message-file", file)
+	if code == 0 || !strings.Contains(stderr, "cannot be combined") {
+		t.Errorf("exit %d, stderr %q", code, stderr)
+	}
+	_, stderr, code = runMainInput(t, api.env(), r, "-title-from-first-line", "title")
+	if code == 0 || !strings.Contains(stderr, "takes no title") {
+		t.Errorf("exit %d, stderr %q", code, stderr)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// ANSI color codes for terminal output
+const (
+	colorRed    = "31"
+	colorGreen  = "32"
+	colorYellow = "33"
+)
+
+// colorMode is one of auto, always or never, set by -color
+var colorMode = "auto"
+
+// useColor reports whether output written to f should be colored. In auto
+// mode that is when f is a terminal and NO_COLOR is not set.
+func useColor(f *os.File) bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps s in the color code when f supports it
+func paint(f *os.File, color, s string) string {
+	if !useColor(f) {
+		return s
+	}
+	return "\033[" + color + "m" + s + "\033[0m"
+}
+
+// warnf logs a warning, in yellow on a terminal
+func warnf(format string, v ...interface{}) {
+	log.Print(paint(os.Stderr, colorYellow, fmt.Sprintf(format, v...)))
+}
+
+// fatal logs err in red and exits with status 1
+func fatal(err error) {
+	log.Fatalln(paint(os.Stderr, colorRed, err.Error()))
+}
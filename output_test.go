@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestColorOutput(t *testing.T) {
+	api := newAPIStub(t)
+	stdout, _, _ := runMain(t, api.env(), "", "-color", "always", "title")
+	if !strings.Contains(stdout, "\033["+colorGreen+"m") {
+		t.Errorf("success not green: %q", stdout)
+	}
+
+	api.setStatus(http.StatusBadRequest)
+	api.setReply(`{"status":0,"request":"r","errors":["user identifier is invalid"]}`)
+	_, stderr, _ := runMain(t, api.env(), "", "-color", "always", "title")
+	if !strings.Contains(stderr, "\033["+colorRed+"m") {
+		t.Errorf("failure not red: %q", stderr)
+	}
+	_, stderr, _ = runMain(t, api.env(), "", "-color", "always", "-at", "yesterday", "title")
+	if !strings.Contains(stderr, "\033["+colorRed+"m") {
+		t.Errorf("usage error not red: %q", stderr)
+	}
+
+	env := append(api.env(), "NO_COLOR=1")
+	_, stderr, _ = runMain(t, env, "", "title")
+	if strings.Contains(stderr, "\033[") {
+		t.Errorf("colored despite NO_COLOR: %q", stderr)
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	old := colorMode
+	t.Cleanup(func() { colorMode = old })
+
+	colorMode = "auto"
+	t.Setenv("NO_COLOR", "1")
+	if got := paint(nil, colorRed, "x"); got != "x" {
+		t.Errorf("auto with NO_COLOR painted %q", got)
+	}
+	colorMode = "always"
+	if got := paint(nil, colorRed, "x"); got != "\033[31mx\033[0m" {
+		t.Errorf("always painted %q", got)
+	}
+	colorMode = "never"
+	t.Setenv("NO_COLOR", "")
+	if got := paint(nil, colorRed, "x"); got != "x" {
+		t.Errorf("never painted %q", got)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gregdel/pushover"
+)
+
+// colorBold is the ANSI code for bold text
+const colorBold = "1"
+
+// printPreview writes a terminal rendering of the glance to f. titleCut and
+// textCut mark the fields PUSHOVER_TRUNCATE shortened.
+func printPreview(f *os.File, glance *pushover.Glance, titleCut, textCut bool) {
+	marker := func(cut bool) string {
+		if !cut {
+			return ""
+		}
+		return " " + paint(f, colorYellow, "[truncated]")
+	}
+	fmt.Fprintf(f, "[%s]\n", glance.DeviceName)
+	fmt.Fprintf(f, "  %s%s\n", paint(f, colorBold, *glance.Title), marker(titleCut))
+	if *glance.Text != "" {
+		text := strings.ReplaceAll(*glance.Text, "\n", "\n  ")
+		fmt.Fprintf(f, "  %s%s\n", text, marker(textCut))
+	}
+	if glance.Count != nil {
+		fmt.Fprintf(f, "  count: %d\n", *glance.Count)
+	}
+	if glance.Percent != nil {
+		fmt.Fprintf(f, "  percent: %d%%\n", *glance.Percent)
+	}
+}
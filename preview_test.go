@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	api := newAPIStub(t)
+	long := strings.Repeat("x", 120)
+	env := append(api.env(), "NO_COLOR=1", "PUSHOVER_TRUNCATE=true")
+	stdout, stderr, code := runMain(t, env, "", "-preview", "-count", "3", "disk full", long)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	for _, want := range []string{"iPhoneIX", "disk full", strings.Repeat("x", 99), "[truncated]", "count: 3"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("%q missing from\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "\033[") {
+		t.Errorf("colored despite NO_COLOR: %q", stdout)
+	}
+	if strings.Contains(stdout, "percent") {
+		t.Errorf("percent shown when not given:\n%s", stdout)
+	}
+	if api.count() != 0 {
+		t.Errorf("preview sent %d requests", api.count())
+	}
+
+	stdout, _, _ = runMain(t, api.env(), "", "-preview", "-color", "always", "disk full")
+	if !strings.Contains(stdout, "\033["+colorBold+"mdisk full") {
+		t.Errorf("title not bold: %q", stdout)
+	}
+}
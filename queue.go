@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gregdel/pushover"
+)
+
+// retryable reports whether a failed send may succeed later, i.e. the
+// API was unreachable or returned a server error
+func retryable(err error) bool {
+	var urlErr *url.Error
+	return errors.Is(err, pushover.ErrHTTPPushover) || errors.As(err, &urlErr)
+}
+
+// enqueue writes the glance to dir as a JSON file named so that files
+// sort in the order they were queued
+func enqueue(dir string, glance *pushover.Glance) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(glance)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("%020d.json", time.Now().UnixNano()))
+	// Write under a temporary name so a flush never sees a partial file
+	if err := os.WriteFile(name+".tmp", data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// flushQueue resends queued glances in order, deleting each on success.
+// It stops at the first send that may succeed later so the order is
+// kept. Files that cannot be decoded are renamed with a .corrupt suffix
+// and ones the API rejects with a .failed suffix, so neither blocks the
+// rest of the queue.
+func flushQueue(dir string, app *pushover.Pushover, recipient *pushover.Recipient) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	sent := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		name := filepath.Join(dir, e.Name())
+		var glance pushover.Glance
+		data, err := os.ReadFile(name)
+		if err == nil {
+			err = json.Unmarshal(data, &glance)
+		}
+		if err != nil {
+			warnf("Moving aside corrupt queue file %s: %s", name, err)
+			if err := os.Rename(name, name+".corrupt"); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := app.SendGlanceUpdate(&glance, recipient); err != nil {
+			if retryable(err) {
+				return fmt.Errorf("sent %d queued, %s failed: %w", sent, e.Name(), err)
+			}
+			warnf("Moving aside rejected queue file %s: %s", name, err)
+			if err := os.Rename(name, name+".failed"); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+		sent++
+	}
+	log.Printf("Sent %d queued updates", sent)
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gregdel/pushover"
+)
+
+func queueFiles(t *testing.T, dir, pattern string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestFlushQueue(t *testing.T) {
+	api := newAPIStub(t)
+	api.useAPI(t)
+	app := pushover.New(testAppKey)
+	recipient := pushover.NewRecipient(testRecipientKey)
+	dir := t.TempDir()
+
+	for _, title := range []string{"first", "second"} {
+		if err := enqueue(dir, &pushover.Glance{Title: pushover.String(title)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(queueFiles(t, dir, "*.json")); n != 2 {
+		t.Fatalf("%d queued files, want 2", n)
+	}
+
+	// Server errors keep the queue intact for a later flush
+	api.setStatus(http.StatusServiceUnavailable)
+	if err := flushQueue(dir, app, recipient); err == nil {
+		t.Fatal("flush succeeded against a failing API")
+	}
+	if n := len(queueFiles(t, dir, "*.json")); n != 2 {
+		t.Fatalf("%d queued files after failed flush, want 2", n)
+	}
+
+	api.setStatus(http.StatusOK)
+	if err := flushQueue(dir, app, recipient); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(queueFiles(t, dir, "*.json")); n != 0 {
+		t.Errorf("%d queued files left after flush", n)
+	}
+	if got := api.last().Get("title"); got != "second" {
+		t.Errorf("last sent %q, want second", got)
+	}
+}
+
+func TestFlushQueueMovesAside(t *testing.T) {
+	api := newAPIStub(t)
+	api.useAPI(t)
+	app := pushover.New(testAppKey)
+	recipient := pushover.NewRecipient(testRecipientKey)
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000001.json"), []byte("{oops"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := enqueue(dir, &pushover.Glance{Title: pushover.String("rejected")}); err != nil {
+		t.Fatal(err)
+	}
+
+	api.setStatus(http.StatusBadRequest)
+	api.setReply(`{"status":0,"request":"r","errors":["device name is invalid"]}`)
+	if err := flushQueue(dir, app, recipient); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(queueFiles(t, dir, "*.json.corrupt")); n != 1 {
+		t.Errorf("%d corrupt files, want 1", n)
+	}
+	if n := len(queueFiles(t, dir, "*.json.failed")); n != 1 {
+		t.Errorf("%d failed files, want 1", n)
+	}
+	if n := len(queueFiles(t, dir, "*.json")); n != 0 {
+		t.Errorf("%d files still queued", n)
+	}
+}
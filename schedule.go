@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Local time layout accepted by -at, besides RFC 3339
+const atLayout = "2006-01-02T15:04:05"
+
+// scheduledTime resolves -after and -at into the time to send at. The
+// zero time means send now.
+func scheduledTime(after time.Duration, at string, now time.Time) (time.Time, error) {
+	if after != 0 && at != "" {
+		return time.Time{}, errors.New("-after and -at cannot be combined")
+	}
+	if after < 0 {
+		return time.Time{}, fmt.Errorf("-after must not be negative, got %s", after)
+	}
+	if after > 0 {
+		return now.Add(after), nil
+	}
+	if at == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		t, err = time.ParseInLocation(atLayout, at, time.Local)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -at %q, use %s or RFC 3339", at, atLayout)
+	}
+	if t.Before(now) {
+		return time.Time{}, fmt.Errorf("-at %s is in the past", at)
+	}
+	return t, nil
+}
+
+// waitUntil blocks until t, returning an error if ctx is done first
+func waitUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.New("scheduled send cancelled")
+	}
+}
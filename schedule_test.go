@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduledTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local)
+
+	got, err := scheduledTime(0, "", now)
+	if err != nil || !got.IsZero() {
+		t.Errorf("no schedule: %v, %v", got, err)
+	}
+
+	got, err = scheduledTime(30*time.Minute, "", now)
+	if err != nil || !got.Equal(now.Add(30*time.Minute)) {
+		t.Errorf("-after 30m: %v, %v", got, err)
+	}
+
+	got, err = scheduledTime(0, "2024-01-01T09:00:00", now)
+	if err != nil || !got.Equal(time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local)) {
+		t.Errorf("-at local: %v, %v", got, err)
+	}
+
+	rfc := now.Add(2 * time.Hour).UTC().Format(time.RFC3339)
+	got, err = scheduledTime(0, rfc, now)
+	if err != nil || !got.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("-at RFC 3339: %v, %v", got, err)
+	}
+
+	for name, tc := range map[string]struct {
+		after time.Duration
+		at    string
+	}{
+		"past":     {0, "2023-12-31T09:00:00"},
+		"combined": {time.Minute, "2024-01-01T09:00:00"},
+		"negative": {-time.Minute, ""},
+		"invalid":  {0, "tomorrow"},
+	} {
+		if _, err := scheduledTime(tc.after, tc.at, now); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	start := time.Now()
+	at := start.Add(50 * time.Millisecond)
+	if err := waitUntil(context.Background(), at); err != nil {
+		t.Fatal(err)
+	}
+	if time.Now().Before(at) {
+		t.Errorf("returned %s early", at.Sub(time.Now()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	if err := waitUntil(ctx, start.Add(time.Hour)); err == nil {
+		t.Error("cancelled wait returned no error")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("cancelled wait took %s", time.Since(start))
+	}
+}
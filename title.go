@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// titleData holds the placeholders available in a title template
+type titleData struct {
+	Time string
+	Host string
+}
+
+// renderTitle substitutes {{.Time}} and {{.Host}} in title
+func renderTitle(title string, now time.Time, host string) (string, error) {
+	tmpl, err := template.New("title").Parse(title)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	err = tmpl.Execute(&b, titleData{
+		Time: now.Format("2006-01-02 15:04"),
+		Host: host,
+	})
+	return b.String(), err
+}
+
+// hostname returns the machine's name for {{.Host}}
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
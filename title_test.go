@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderTitle(t *testing.T) {
+	now := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	for title, want := range map[string]string{
+		"Deploy {{.Time}}":       "Deploy 2024-01-02 09:30",
+		"Backup on {{.Host}}":    "Backup on web-03",
+		"{{.Host}} at {{.Time}}": "web-03 at 2024-01-02 09:30",
+		"No placeholders":        "No placeholders",
+	} {
+		got, err := renderTitle(title, now, "web-03")
+		if err != nil {
+			t.Errorf("%q: %s", title, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q rendered %q, want %q", title, got, want)
+		}
+	}
+
+	for _, bad := range []string{"{{.Priority}}", "{{.Time"} {
+		if _, err := renderTitle(bad, now, "web-03"); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}